@@ -0,0 +1,103 @@
+package xbmp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+const (
+	benchWidth  = 2048
+	benchHeight = 1536
+)
+
+// benchmarkRGBASource 构造一张用于 32 位解码基准测试的 RGBA 图像
+func benchmarkRGBASource() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, benchWidth, benchHeight))
+	for y := 0; y < benchHeight; y++ {
+		for x := 0; x < benchWidth; x++ {
+			img.SetRGBA(x, y, color.RGBA{
+				R: byte(x),
+				G: byte(y),
+				B: byte(x + y),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// benchmarkNRGBA64Source 构造一张用于 24 位解码基准测试的图像（NRGBA64 不命中
+// newBitmapFileFor 的特化分支，因此会走通用的 24 位编码路径）
+func benchmarkNRGBA64Source() *image.NRGBA64 {
+	img := image.NewNRGBA64(image.Rect(0, 0, benchWidth, benchHeight))
+	for y := 0; y < benchHeight; y++ {
+		for x := 0; x < benchWidth; x++ {
+			img.SetNRGBA64(x, y, color.NRGBA64{
+				R: uint16(x) << 8,
+				G: uint16(y) << 8,
+				B: uint16(x+y) << 8,
+				A: 0xFFFF,
+			})
+		}
+	}
+	return img
+}
+
+// benchmarkPalettedSource 构造一张用于 8 位调色板解码基准测试的图像
+func benchmarkPalettedSource() *image.Paletted {
+	pal := make(color.Palette, 256)
+	for i := range pal {
+		pal[i] = color.RGBA{R: byte(i), G: byte(255 - i), B: byte(i / 2), A: 255}
+	}
+	img := image.NewPaletted(image.Rect(0, 0, benchWidth, benchHeight), pal)
+	for y := 0; y < benchHeight; y++ {
+		for x := 0; x < benchWidth; x++ {
+			img.SetColorIndex(x, y, byte((x+y)%256))
+		}
+	}
+	return img
+}
+
+// encodeToBytes 用 Encode 把图像编码为 BMP 字节，供基准测试反复解码
+func encodeToBytes(tb testing.TB, m image.Image) []byte {
+	var buf bytes.Buffer
+	if err := Encode(&buf, m); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkDecode24(b *testing.B) {
+	data := encodeToBytes(b, benchmarkNRGBA64Source())
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode32(b *testing.B) {
+	data := encodeToBytes(b, benchmarkRGBASource())
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode8Paletted(b *testing.B) {
+	data := encodeToBytes(b, benchmarkPalettedSource())
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
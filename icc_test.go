@@ -0,0 +1,118 @@
+package xbmp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+// buildV5BMP 手工构造一个 BITMAPV5HEADER、32 位 BITFIELDS、内嵌 ICC 配置文件的 BMP。
+// profileSize 独立于实际写入的 iccData 长度传入，便于构造越界的 ProfileSize 做边界测试。
+func buildV5BMP(profileSize uint32) []byte {
+	iccData := []byte("fake-icc-profile-data")
+
+	var info bytes.Buffer
+	binary.Write(&info, binary.LittleEndian, uint32(v5HeaderSize))
+	binary.Write(&info, binary.LittleEndian, int32(2))   // Width
+	binary.Write(&info, binary.LittleEndian, int32(2))   // Height
+	binary.Write(&info, binary.LittleEndian, uint16(1))  // Planes
+	binary.Write(&info, binary.LittleEndian, uint16(32)) // BitCount
+	binary.Write(&info, binary.LittleEndian, uint32(biBitFields))
+	binary.Write(&info, binary.LittleEndian, uint32(0))          // SizeImage
+	binary.Write(&info, binary.LittleEndian, int32(0))           // XPelsPerMeter
+	binary.Write(&info, binary.LittleEndian, int32(0))           // YPelsPerMeter
+	binary.Write(&info, binary.LittleEndian, uint32(0))          // ClrUsed
+	binary.Write(&info, binary.LittleEndian, uint32(0))          // ClrImportant
+	binary.Write(&info, binary.LittleEndian, uint32(0x00FF0000)) // RedMask
+	binary.Write(&info, binary.LittleEndian, uint32(0x0000FF00)) // GreenMask
+	binary.Write(&info, binary.LittleEndian, uint32(0x000000FF)) // BlueMask
+	binary.Write(&info, binary.LittleEndian, uint32(0xFF000000)) // AlphaMask
+	binary.Write(&info, binary.LittleEndian, uint32(csTypeProfileEmbedded))
+	for i := 0; i < 9; i++ {
+		binary.Write(&info, binary.LittleEndian, int32(0)) // Endpoints
+	}
+	binary.Write(&info, binary.LittleEndian, uint32(0)) // GammaRed
+	binary.Write(&info, binary.LittleEndian, uint32(0)) // GammaGreen
+	binary.Write(&info, binary.LittleEndian, uint32(0)) // GammaBlue
+	binary.Write(&info, binary.LittleEndian, uint32(0)) // Intent
+
+	// ProfileData 相对信息头起始位置，紧跟在它自己和 ProfileSize/Reserved 这三个字段之后。
+	profileOffset := uint32(info.Len()) + 12
+	binary.Write(&info, binary.LittleEndian, profileOffset)
+	binary.Write(&info, binary.LittleEndian, profileSize)
+	binary.Write(&info, binary.LittleEndian, uint32(0)) // Reserved
+
+	headerAndICC := append(info.Bytes(), iccData...)
+
+	// 2x2、自下而上存储：文件第一行是底部（显示坐标 y=1），第二行是顶部（y=0）。
+	pixelData := []byte{
+		0, 0, 255, 255, 0, 255, 0, 255, // 文件第一行（y=1）：红色, 绿色
+		255, 0, 0, 255, 10, 20, 30, 255, // 文件第二行（y=0）：蓝色, {B:10,G:20,R:30}
+	}
+
+	var file bytes.Buffer
+	file.WriteString("BM")
+	binary.Write(&file, binary.LittleEndian, uint32(0)) // FileSize，稍后回填
+	binary.Write(&file, binary.LittleEndian, uint32(0)) // Reserved
+	binary.Write(&file, binary.LittleEndian, uint32(bmpFileHeaderSize+len(headerAndICC)))
+	file.Write(headerAndICC)
+	file.Write(pixelData)
+
+	buf := file.Bytes()
+	binary.LittleEndian.PutUint32(buf[2:6], uint32(len(buf)))
+	return buf
+}
+
+// 验证 BITMAPV5HEADER、信息头内嵌的 BITFIELDS 掩码以及 ICC 配置文件均被正确解析
+func TestDecodeV5HeaderWithICC(t *testing.T) {
+	img, err := Decode(bytes.NewReader(buildV5BMP(uint32(len("fake-icc-profile-data")))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iccImg, ok := img.(Image)
+	if !ok {
+		t.Fatal("解码结果未实现 xbmp.Image 接口")
+	}
+	if string(iccImg.ICCProfile()) != "fake-icc-profile-data" {
+		t.Errorf("ICC 配置文件内容不符合预期: %q", iccImg.ICCProfile())
+	}
+
+	if c := img.At(0, 0).(color.RGBA); c.R != 0 || c.G != 0 || c.B != 255 {
+		t.Errorf("(0,0) 颜色不符合预期: %+v", c)
+	}
+	if c := img.At(0, 1).(color.RGBA); c.R != 255 || c.G != 0 || c.B != 0 {
+		t.Errorf("(0,1) 颜色不符合预期: %+v", c)
+	}
+}
+
+// 验证声明的 ProfileSize 超出文件实际大小时会报错，而不是按该（可被伪造得
+// 极大的）字段直接分配内存。
+func TestDecodeV5HeaderRejectsOversizedProfileSize(t *testing.T) {
+	_, err := Decode(bytes.NewReader(buildV5BMP(0xFFFFFFFE)))
+	if err == nil {
+		t.Fatal("期望因 ProfileSize 越界而报错，实际未报错")
+	}
+}
+
+// 验证通过不可定位的读取器（例如 image.Decode 内部用 bufio.Reader 包装后传入
+// 的管道/HTTP 响应体）也能解码带 ICC 配置文件的 BMP，而不是报错要求可定位。
+func TestDecodeV5HeaderWithICCWithoutSeeker(t *testing.T) {
+	img, err := Decode(&nonSeekingReader{r: bufio.NewReader(bytes.NewReader(buildV5BMP(uint32(len("fake-icc-profile-data")))))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iccImg, ok := img.(Image)
+	if !ok {
+		t.Fatal("解码结果未实现 xbmp.Image 接口")
+	}
+	if string(iccImg.ICCProfile()) != "fake-icc-profile-data" {
+		t.Errorf("ICC 配置文件内容不符合预期: %q", iccImg.ICCProfile())
+	}
+	if c := img.At(0, 0).(color.RGBA); c.R != 0 || c.G != 0 || c.B != 255 {
+		t.Errorf("(0,0) 颜色不符合预期: %+v", c)
+	}
+}
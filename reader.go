@@ -1,6 +1,7 @@
 package xbmp
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"image"
@@ -10,10 +11,24 @@ import (
 
 const (
 	bmpFileHeaderSize = 14
-	coreHeaderSize    = 12 // BITMAPCOREHEADER
-	infoHeaderSize    = 40 // BITMAPINFOHEADER
+	coreHeaderSize    = 12  // BITMAPCOREHEADER
+	infoHeaderSize    = 40  // BITMAPINFOHEADER
+	v2HeaderSize      = 52  // BITMAPV2INFOHEADER，追加 RGB 掩码（未正式文档化）
+	v3HeaderSize      = 56  // BITMAPV3INFOHEADER，追加 Alpha 掩码
+	v4HeaderSize      = 108 // BITMAPV4HEADER，追加色彩空间/伽马信息
+	v5HeaderSize      = 124 // BITMAPV5HEADER，追加 ICC 配置文件定位信息
 	biRGB             = 0
+	biRLE8            = 1
+	biRLE4            = 2
 	biBitFields       = 3
+
+	csTypeProfileEmbedded = 0x4D424544 // 'MBED'
+	csTypeProfileLinked   = 0x4C494E4B // 'LINK'
+
+	// maxDataOffsetGap 是非可定位读取器在跳转到 DataOffset 时允许丢弃的最大字节数。
+	// DataOffset 来自文件头、完全由输入数据决定，不能直接信任；这里给一个远超
+	// 头部+调色板+ICC 配置文件实际占用的上限，而不是依赖同样不可信的 FileSize 字段。
+	maxDataOffsetGap = 16 << 20 // 16 MiB
 )
 
 type FileHeader struct {
@@ -39,20 +54,162 @@ type InfoHeader struct {
 	GreenMask     uint32
 	BlueMask      uint32
 	AlphaMask     uint32
+
+	// 以下字段仅在 Size >= v4HeaderSize（BITMAPV4HEADER/BITMAPV5HEADER）时有效。
+	CSType      uint32
+	Endpoints   [9]int32 // CIEXYZTRIPLE：R/G/B 各 3 个 FXPT2DOT30 分量
+	GammaRed    uint32
+	GammaGreen  uint32
+	GammaBlue   uint32
+	Intent      uint32 // 仅 Size >= v5HeaderSize 时有效
+	ProfileData uint32 // ICC 配置文件相对信息头起始位置的偏移，仅 Size >= v5HeaderSize 时有效
+	ProfileSize uint32 // 仅 Size >= v5HeaderSize 时有效
+}
+
+// Image 在标准 image.Image 之上暴露 BMP 内嵌的 ICC 颜色配置文件（如果存在）。
+type Image interface {
+	image.Image
+	ICCProfile() []byte
+}
+
+// iccImage 包装 Decode 产生的图像并附带其 ICC 配置文件数据。
+type iccImage struct {
+	image.Image
+	profile []byte
+}
+
+func (i *iccImage) ICCProfile() []byte { return i.profile }
+
+func init() {
+	image.RegisterFormat("bmp", "BM????", Decode, DecodeConfig)
 }
 
 func Decode(r io.Reader) (image.Image, error) {
+	// 不可定位的读取器（管道、HTTP 响应体……）本身读不了多次；用 TeeReader 把
+	// 读过的字节顺带存一份，这样如果后面发现图像带 ICC 配置文件、需要随机访问，
+	// 可以把剩余数据一并读入内存，退化成一个可定位的 bytes.Reader。
+	var tee *bytes.Buffer
+	if _, ok := r.(io.Seeker); !ok {
+		tee = new(bytes.Buffer)
+		r = io.TeeReader(r, tee)
+	}
+
+	fileHdr, info, err := readHeaders(r)
+	if err != nil {
+		return nil, err
+	}
+	consumed := int64(bmpFileHeaderSize) + int64(info.Size)
+
+	// 处理调色板
+	palette, err := parsePalette(r, info)
+	if err != nil {
+		return nil, err
+	}
+	consumed += int64(len(palette)) * 4
+
+	// 处理位掩码：只有 40 字节的 BITMAPINFOHEADER 才把掩码单独跟在调色板之后，
+	// V2 及以上版本的掩码已经在 readHeaders 中从信息头本身解析出来了。
+	if info.Compression == biBitFields && info.Size < v2HeaderSize {
+		masks := make([]uint32, 4)
+		if err := binary.Read(r, binary.LittleEndian, &masks); err != nil {
+			return nil, err
+		}
+		info.RedMask, info.GreenMask, info.BlueMask, info.AlphaMask = masks[0], masks[1], masks[2], masks[3]
+		consumed += 16
+	}
+
+	seeker, isSeeker := r.(io.Seeker)
+	if !isSeeker && infoHasICCProfile(info) {
+		// ICC 配置文件可能位于像素数据前面或后面的任意偏移处，不可定位的读取器
+		// 无法直接跳转读取；把剩余内容读完（连同 tee 已经缓存的部分）换来一个
+		// 可定位的 bytes.Reader，再从 consumed 处继续解码。
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return nil, err
+		}
+		buffered := bytes.NewReader(tee.Bytes())
+		if _, err := buffered.Seek(consumed, io.SeekStart); err != nil {
+			return nil, err
+		}
+		r, seeker, isSeeker = buffered, buffered, true
+	}
+
+	// 读取内嵌/链接的 ICC 配置文件（如果有）
+	iccProfile, err := readICCProfile(r, info, fileHdr.FileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// 跳转到像素数据：可定位的读取器直接 Seek；否则说明 r 是管道、HTTP 响应体
+	// 一类的流式读取器，改为读取并丢弃中间的字节前进到 DataOffset。
+	if isSeeker {
+		if _, err := seeker.Seek(int64(fileHdr.DataOffset), io.SeekStart); err != nil {
+			return nil, err
+		}
+	} else {
+		gap := int64(fileHdr.DataOffset) - consumed
+		if gap < 0 {
+			return nil, errors.New("xbmp: data offset precedes already consumed header bytes")
+		}
+		if gap > maxDataOffsetGap {
+			return nil, errors.New("xbmp: data offset exceeds maximum supported gap")
+		}
+		if _, err := io.CopyN(io.Discard, r, gap); err != nil {
+			return nil, err
+		}
+	}
+
+	// 创建图像
+	img, err := decodePixelData(r, info, palette)
+	if err != nil {
+		return nil, err
+	}
+	if iccProfile != nil {
+		return &iccImage{Image: img, profile: iccProfile}, nil
+	}
+	return img, nil
+}
+
+// DecodeConfig 只解析文件头、信息头和调色板，不读取像素数据。
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	_, info, err := readHeaders(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	var model color.Model
+	switch {
+	case info.BitCount <= 8:
+		palette, err := parsePalette(r, info)
+		if err != nil {
+			return image.Config{}, err
+		}
+		model = color.Palette(palette)
+	case info.BitCount == 16:
+		model = color.RGBA64Model
+	default:
+		model = color.RGBAModel
+	}
+
+	height := int(info.Height)
+	if height < 0 {
+		height = -height
+	}
+	return image.Config{ColorModel: model, Width: int(info.Width), Height: height}, nil
+}
+
+// readHeaders 解析 BITMAPFILEHEADER 和信息头，调用方负责读取其后的调色板/像素数据。
+func readHeaders(r io.Reader) (FileHeader, InfoHeader, error) {
 	var fileHeader FileHeader
 	if err := binary.Read(r, binary.LittleEndian, &fileHeader); err != nil {
-		return nil, err
+		return FileHeader{}, InfoHeader{}, err
 	}
 	if string(fileHeader.Signature[:]) != "BM" {
-		return nil, errors.New("invalid BMP signature")
+		return FileHeader{}, InfoHeader{}, errors.New("invalid BMP signature")
 	}
 
 	var headerSize uint32
 	if err := binary.Read(r, binary.LittleEndian, &headerSize); err != nil {
-		return nil, err
+		return FileHeader{}, InfoHeader{}, err
 	}
 
 	var info InfoHeader
@@ -67,50 +224,97 @@ func Decode(r io.Reader) (image.Image, error) {
 			BitCount uint16
 		}
 		if err := binary.Read(r, binary.LittleEndian, &core); err != nil {
-			return nil, err
+			return FileHeader{}, InfoHeader{}, err
 		}
 		info.Width = int32(core.Width)
 		info.Height = int32(core.Height)
 		info.Planes = core.Planes
 		info.BitCount = core.BitCount
 		info.Compression = biRGB
-	case infoHeaderSize:
-		remainingHeader := make([]byte, infoHeaderSize-4)
+	case infoHeaderSize, v2HeaderSize, v3HeaderSize, v4HeaderSize, v5HeaderSize:
+		remainingHeader := make([]byte, headerSize-4)
 		if _, err := io.ReadFull(r, remainingHeader); err != nil {
-			return nil, err
+			return FileHeader{}, InfoHeader{}, err
 		}
 		info.Width = int32(binary.LittleEndian.Uint32(remainingHeader[0:4]))
 		info.Height = int32(binary.LittleEndian.Uint32(remainingHeader[4:8]))
 		info.Planes = binary.LittleEndian.Uint16(remainingHeader[8:10])
 		info.BitCount = binary.LittleEndian.Uint16(remainingHeader[10:12])
 		info.Compression = binary.LittleEndian.Uint32(remainingHeader[12:16])
+		info.SizeImage = binary.LittleEndian.Uint32(remainingHeader[16:20])
+		info.XPelsPerMeter = int32(binary.LittleEndian.Uint32(remainingHeader[20:24]))
+		info.YPelsPerMeter = int32(binary.LittleEndian.Uint32(remainingHeader[24:28]))
+		info.ClrUsed = binary.LittleEndian.Uint32(remainingHeader[28:32])
+		info.ClrImportant = binary.LittleEndian.Uint32(remainingHeader[32:36])
+
+		// BITMAPV2INFOHEADER 及以上版本把 BITFIELDS 掩码直接放进信息头，
+		// 不再像 40 字节头那样跟在调色板之后单独给出。
+		if headerSize >= v2HeaderSize {
+			info.RedMask = binary.LittleEndian.Uint32(remainingHeader[36:40])
+			info.GreenMask = binary.LittleEndian.Uint32(remainingHeader[40:44])
+			info.BlueMask = binary.LittleEndian.Uint32(remainingHeader[44:48])
+		}
+		if headerSize >= v3HeaderSize {
+			info.AlphaMask = binary.LittleEndian.Uint32(remainingHeader[48:52])
+		}
+		if headerSize >= v4HeaderSize {
+			info.CSType = binary.LittleEndian.Uint32(remainingHeader[52:56])
+			for i := range info.Endpoints {
+				off := 56 + i*4
+				info.Endpoints[i] = int32(binary.LittleEndian.Uint32(remainingHeader[off : off+4]))
+			}
+			info.GammaRed = binary.LittleEndian.Uint32(remainingHeader[92:96])
+			info.GammaGreen = binary.LittleEndian.Uint32(remainingHeader[96:100])
+			info.GammaBlue = binary.LittleEndian.Uint32(remainingHeader[100:104])
+		}
+		if headerSize >= v5HeaderSize {
+			info.Intent = binary.LittleEndian.Uint32(remainingHeader[104:108])
+			info.ProfileData = binary.LittleEndian.Uint32(remainingHeader[108:112])
+			info.ProfileSize = binary.LittleEndian.Uint32(remainingHeader[112:116])
+		}
 	default:
-		return nil, errors.New("unsupported BMP header")
+		return FileHeader{}, InfoHeader{}, errors.New("unsupported BMP header")
 	}
 
-	// 处理调色板
-	palette, err := parsePalette(r, info)
-	if err != nil {
-		return nil, err
+	return fileHeader, info, nil
+}
+
+// infoHasICCProfile 报告信息头是否声明了内嵌/链接的 ICC 配置文件。
+func infoHasICCProfile(info InfoHeader) bool {
+	if info.Size < v5HeaderSize || info.ProfileSize == 0 {
+		return false
 	}
+	return info.CSType == csTypeProfileEmbedded || info.CSType == csTypeProfileLinked
+}
 
-	// 处理位掩码
-	if info.Compression == biBitFields {
-		// 读取颜色掩码
-		masks := make([]uint32, 4)
-		if err := binary.Read(r, binary.LittleEndian, &masks); err != nil {
-			return nil, err
-		}
-		info.RedMask, info.GreenMask, info.BlueMask, info.AlphaMask = masks[0], masks[1], masks[2], masks[3]
+// readICCProfile 在信息头声明了内嵌/链接的 ICC 配置文件时读取其原始字节。
+// ProfileData 是相对信息头起始位置（文件偏移 bmpFileHeaderSize）的偏移量。
+// fileSize 来自 BITMAPFILEHEADER，用来在分配 profile 缓冲区前校验 ProfileData/
+// ProfileSize 没有越界，避免按未经校验的头部字段分配任意大小的内存。
+func readICCProfile(r io.Reader, info InfoHeader, fileSize uint32) ([]byte, error) {
+	if !infoHasICCProfile(info) {
+		return nil, nil
 	}
 
-	// 跳转到像素数据
-	if _, err := r.(io.Seeker).Seek(int64(fileHeader.DataOffset), io.SeekStart); err != nil {
+	iccOffset := int64(bmpFileHeaderSize) + int64(info.ProfileData)
+	iccEnd := iccOffset + int64(info.ProfileSize)
+	if iccOffset < int64(bmpFileHeaderSize) || iccEnd < iccOffset || iccEnd > int64(fileSize) {
+		return nil, errors.New("xbmp: ICC profile offset/size exceeds file size")
+	}
+
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return nil, errors.New("xbmp: embedded ICC profile requires a seekable reader")
+	}
+	if _, err := seeker.Seek(iccOffset, io.SeekStart); err != nil {
 		return nil, err
 	}
 
-	// 创建图像
-	return decodePixelData(r, info, palette)
+	profile := make([]byte, info.ProfileSize)
+	if _, err := io.ReadFull(r, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
 }
 
 func parsePalette(r io.Reader, info InfoHeader) ([]color.Color, error) {
@@ -146,6 +350,9 @@ func decodePixelData(r io.Reader, info InfoHeader, palette []color.Color) (image
 
 	switch info.BitCount {
 	case 1, 4, 8:
+		if info.Compression == biRLE4 || info.Compression == biRLE8 {
+			return readRLEData(r, rect, palette, info)
+		}
 		img = image.NewPaletted(rect, palette)
 		err := readIndexedData(r, img.(*image.Paletted), info)
 		return img, err
@@ -163,7 +370,8 @@ func decodePixelData(r io.Reader, info InfoHeader, palette []color.Color) (image
 	}
 }
 
-// readIndexedData 处理 1/4/8 位调色板图像
+// readIndexedData 处理 1/4/8 位调色板图像，直接写入 img.Pix 以避免逐像素的
+// 边界检查和方法调用开销。
 func readIndexedData(r io.Reader, img *image.Paletted, info InfoHeader) error {
 	width := int(info.Width)
 	height := int(info.Height)
@@ -171,12 +379,10 @@ func readIndexedData(r io.Reader, img *image.Paletted, info InfoHeader) error {
 		height = -height
 	}
 
-	bitsPerPixel := int(info.BitCount)
-	pixelsPerByte := 8 / bitsPerPixel
-	bitMask := byte(1<<bitsPerPixel - 1)
+	bitsPerPixel := info.BitCount
 
 	// 计算每行字节数（4字节对齐）
-	bytesPerRow := (width*bitsPerPixel + 31) / 32 * 4
+	bytesPerRow := (width*int(bitsPerPixel) + 31) / 32 * 4
 	row := make([]byte, bytesPerRow)
 
 	for y := 0; y < height; y++ {
@@ -190,21 +396,132 @@ func readIndexedData(r io.Reader, img *image.Paletted, info InfoHeader) error {
 			targetY = height - 1 - y
 		}
 
-		for x := 0; x < width; x++ {
-			// 计算字节位置和位偏移
-			bytePos := x / pixelsPerByte
-			bitOffset := uint((x % pixelsPerByte) * bitsPerPixel)
-			if info.BitCount == 1 {
-				bitOffset = 7 - bitOffset // 1-bit 高位在前
+		offset := img.PixOffset(0, targetY)
+		dst := img.Pix[offset : offset+width]
+		unpackIndices(dst, row, bitsPerPixel)
+	}
+	return nil
+}
+
+// unpackIndices 把按 bitCount 打包的一行像素数据解包为 dst 中每像素一项的调色板下标，
+// 与 writer.go 中 packIndices 的打包方式一一对应。
+func unpackIndices(dst, row []byte, bitCount uint16) {
+	switch bitCount {
+	case 8:
+		copy(dst, row[:len(dst)])
+	case 4:
+		for x := range dst {
+			shift := uint(x%2) * 4
+			dst[x] = (row[x/2] >> shift) & 0x0F
+		}
+	case 1:
+		for x := range dst {
+			bitOffset := uint(7 - x%8)
+			dst[x] = (row[x/8] >> bitOffset) & 1
+		}
+	}
+}
+
+// readRLEData 解码 BI_RLE4/BI_RLE8 压缩的调色板数据。
+// 码流由两字节记录组成：编码模式 (count, index) 连续写入 count 个像素；
+// 绝对模式 (0, n) 后跟 n 个字面像素（按 16 位边界补齐）；(0,0) 结束当前行；
+// (0,1) 结束位图；(0,2,dx,dy) 按 (dx,dy) 移动当前坐标。未写入的像素保持调色板索引 0。
+func readRLEData(r io.Reader, rect image.Rectangle, palette []color.Color, info InfoHeader) (image.Image, error) {
+	if info.Compression == biRLE4 && info.BitCount != 4 {
+		return nil, errors.New("xbmp: BI_RLE4 requires a 4-bit BMP")
+	}
+	if info.Compression == biRLE8 && info.BitCount != 8 {
+		return nil, errors.New("xbmp: BI_RLE8 requires an 8-bit BMP")
+	}
+
+	height := rect.Dy()
+	img := image.NewPaletted(rect, palette)
+
+	targetY := func(y int) int {
+		if info.Height > 0 {
+			return height - 1 - y
+		}
+		return y
+	}
+
+	readLiteral := func(n, x, y int) error {
+		if info.Compression == biRLE8 {
+			lit := make([]byte, n+n%2)
+			if _, err := io.ReadFull(r, lit); err != nil {
+				return err
 			}
+			ty := targetY(y)
+			for i := 0; i < n; i++ {
+				img.SetColorIndex(x+i, ty, lit[i])
+			}
+			return nil
+		}
 
-			// 提取颜色索引
-			b := row[bytePos]
-			idx := (b >> bitOffset) & bitMask
-			img.SetColorIndex(x, targetY, idx)
+		lit := make([]byte, ((n+1)/2+1)/2*2)
+		if _, err := io.ReadFull(r, lit); err != nil {
+			return err
+		}
+		ty := targetY(y)
+		for i := 0; i < n; i++ {
+			b := lit[i/2]
+			if i%2 == 0 {
+				img.SetColorIndex(x+i, ty, b>>4)
+			} else {
+				img.SetColorIndex(x+i, ty, b&0x0F)
+			}
+		}
+		return nil
+	}
+
+	x, y := 0, 0
+	two := make([]byte, 2)
+	for {
+		if _, err := io.ReadFull(r, two); err != nil {
+			return nil, err
+		}
+		count, second := two[0], two[1]
+
+		if count != 0 {
+			// 编码模式：count 个像素，颜色来自 second
+			ty := targetY(y)
+			if info.Compression == biRLE8 {
+				for i := 0; i < int(count); i++ {
+					img.SetColorIndex(x+i, ty, second)
+				}
+			} else {
+				hi, lo := second>>4, second&0x0F
+				for i := 0; i < int(count); i++ {
+					if i%2 == 0 {
+						img.SetColorIndex(x+i, ty, hi)
+					} else {
+						img.SetColorIndex(x+i, ty, lo)
+					}
+				}
+			}
+			x += int(count)
+			continue
+		}
+
+		switch second {
+		case 0: // 行结束
+			x, y = 0, y+1
+		case 1: // 位图结束
+			return img, nil
+		case 2: // 增量移动
+			delta := make([]byte, 2)
+			if _, err := io.ReadFull(r, delta); err != nil {
+				return nil, err
+			}
+			x += int(delta[0])
+			y += int(delta[1])
+		default: // 绝对模式：接下来 second 个像素为字面量
+			n := int(second)
+			if err := readLiteral(n, x, y); err != nil {
+				return nil, err
+			}
+			x += n
 		}
 	}
-	return nil
 }
 
 // read16BitData 处理 16 位色深（支持 RGB555/RGB565/BITFIELDS）
@@ -243,6 +560,9 @@ func read16BitData(r io.Reader, img *image.RGBA64, info InfoHeader) (image.Image
 			targetY = height - 1 - y
 		}
 
+		offset := img.PixOffset(0, targetY)
+		dst := img.Pix[offset : offset+width*8]
+
 		for x := 0; x < width; x++ {
 			// 读取16位值（小端序）
 			pixel := binary.LittleEndian.Uint16(row[x*2 : x*2+2])
@@ -260,18 +580,22 @@ func read16BitData(r io.Reader, img *image.RGBA64, info InfoHeader) (image.Image
 			b = (b >> bShift) << (16 - bBits)
 			b |= b >> bBits
 
-			img.SetRGBA64(x, targetY, color.RGBA64{
-				R: uint16(r),
-				G: uint16(g),
-				B: uint16(b),
-				A: 0xFFFF,
-			})
+			offset := x * 8
+			dst[offset+0] = byte(r >> 8)
+			dst[offset+1] = byte(r)
+			dst[offset+2] = byte(g >> 8)
+			dst[offset+3] = byte(g)
+			dst[offset+4] = byte(b >> 8)
+			dst[offset+5] = byte(b)
+			dst[offset+6] = 0xFF
+			dst[offset+7] = 0xFF
 		}
 	}
 	return img, nil
 }
 
-// read32BitData 处理 32 位色深（支持 RGBA/BITFIELDS）
+// read32BitData 处理 32 位色深（支持 RGBA/BITFIELDS）。默认掩码（无 BITFIELDS 或
+// BITFIELDS 掩码恰好等于标准 BGRA 布局）下直接按字节交换写入，跳过掩码位移计算。
 func read32BitData(r io.Reader, img *image.RGBA, info InfoHeader) (image.Image, error) {
 	width := int(info.Width)
 	height := int(info.Height)
@@ -291,7 +615,9 @@ func read32BitData(r io.Reader, img *image.RGBA, info InfoHeader) (image.Image,
 		alphaMask = info.AlphaMask
 	}
 
-	// 计算位掩码参数
+	standardBGRA := redMask == 0x00FF0000 && greenMask == 0x0000FF00 && blueMask == 0x000000FF
+
+	// 计算位掩码参数（仅在非标准掩码时才会用到）
 	rShift, _ := maskShift(redMask)
 	gShift, _ := maskShift(greenMask)
 	bShift, _ := maskShift(blueMask)
@@ -310,20 +636,40 @@ func read32BitData(r io.Reader, img *image.RGBA, info InfoHeader) (image.Image,
 			targetY = height - 1 - y
 		}
 
-		for x := 0; x < width; x++ {
-			pixel := binary.LittleEndian.Uint32(row[x*4 : x*4+4])
-
-			r := byte((pixel & redMask) >> rShift)
-			g := byte((pixel & greenMask) >> gShift)
-			b := byte((pixel & blueMask) >> bShift)
-			a := byte((pixel & alphaMask) >> aShift)
-
-			// 如果无Alpha通道，设为255
-			if alphaMask == 0 {
-				a = 0xFF
+		offset := img.PixOffset(0, targetY)
+		dst := img.Pix[offset : offset+width*4]
+
+		switch {
+		case standardBGRA && alphaMask == 0xFF000000:
+			// 快速路径：标准 32 位 BGRA，只需交换 R/B 字节，无需逐像素位移
+			for x := 0; x < width; x++ {
+				so, do := x*4, x*4
+				dst[do+0] = row[so+2]
+				dst[do+1] = row[so+1]
+				dst[do+2] = row[so+0]
+				dst[do+3] = row[so+3]
+			}
+		case standardBGRA && alphaMask == 0:
+			for x := 0; x < width; x++ {
+				so, do := x*4, x*4
+				dst[do+0] = row[so+2]
+				dst[do+1] = row[so+1]
+				dst[do+2] = row[so+0]
+				dst[do+3] = 0xFF
+			}
+		default:
+			for x := 0; x < width; x++ {
+				pixel := binary.LittleEndian.Uint32(row[x*4 : x*4+4])
+				do := x * 4
+				dst[do+0] = byte((pixel & redMask) >> rShift)
+				dst[do+1] = byte((pixel & greenMask) >> gShift)
+				dst[do+2] = byte((pixel & blueMask) >> bShift)
+				if alphaMask == 0 {
+					dst[do+3] = 0xFF
+				} else {
+					dst[do+3] = byte((pixel & alphaMask) >> aShift)
+				}
 			}
-
-			img.Set(x, targetY, color.RGBA{R: r, G: g, B: b, A: a})
 		}
 	}
 	return img, nil
@@ -347,29 +693,36 @@ func maskShift(mask uint32) (shift, bits int) {
 	return
 }
 
-// 示例：读取24位色深数据
+// read24BitData 处理 24 位色深数据
 func read24BitData(r io.Reader, img *image.RGBA, info InfoHeader) (image.Image, error) {
-	bytesPerRow := (int(info.Width)*3 + 3) &^ 3
+	width := int(info.Width)
+	height := int(info.Height)
+	if height < 0 {
+		height = -height
+	}
+
+	bytesPerRow := (width*3 + 3) &^ 3
 	row := make([]byte, bytesPerRow)
 
-	for y := 0; y < int(info.Height); y++ {
+	for y := 0; y < height; y++ {
 		if _, err := io.ReadFull(r, row); err != nil {
 			return nil, err
 		}
 
 		targetY := y
 		if info.Height > 0 {
-			targetY = int(info.Height) - 1 - y // 倒序
+			targetY = height - 1 - y // 倒序
 		}
 
-		for x := 0; x < int(info.Width); x++ {
-			offset := x * 3
-			img.Set(x, targetY, color.RGBA{
-				R: row[offset+2],
-				G: row[offset+1],
-				B: row[offset],
-				A: 255,
-			})
+		offset := img.PixOffset(0, targetY)
+		dst := img.Pix[offset : offset+width*4]
+
+		for x := 0; x < width; x++ {
+			so, do := x*3, x*4
+			dst[do+0] = row[so+2]
+			dst[do+1] = row[so+1]
+			dst[do+2] = row[so+0]
+			dst[do+3] = 255
 		}
 	}
 	return img, nil
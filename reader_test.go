@@ -1,17 +1,23 @@
-package bmp
+package xbmp
 
 import (
+	"bytes"
+	"image"
 	"image/color"
-	"os"
 	"testing"
 )
 
-// when there are no headers or data is empty
+// 验证 Decode 能正确还原一张最小的 BMP 图像
 func TestDecode(t *testing.T) {
-	file, _ := os.Open("data/sign.bmp")
-	defer file.Close()
+	src := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	src.Set(1, 1, color.RGBA{1, 1, 1, 255})
 
-	img, err := Decode(file)
+	var buf bytes.Buffer
+	if err := Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Decode(bytes.NewReader(buf.Bytes()))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,159 @@
+package xbmp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// buildRLE8BMP 手工构造一个 6x2 的 BI_RLE8 码流，覆盖 EncodeRLE 从不生成、
+// 因此无法通过编解码往返测试覆盖到的绝对模式与增量移动：
+//
+//	流内第一行（显示坐标 y=1，自下而上存储）：
+//	  (3,1)          编码模式，索引1 连写3个 -> x=0,1,2
+//	  (0,3) 2,3,0,0  绝对模式，3个字面像素（末尾补一字节对齐）-> x=3,4,5
+//	  (0,0)          行结束
+//	流内第二行（显示坐标 y=0）：
+//	  (0,2) 2,0      增量移动，x += 2 -> x=2（y 不变）
+//	  (2,3)          编码模式，索引3 连写2个 -> x=2,3
+//	  (0,1)          位图结束（x=0,1 和 x=4,5 未写入，应保持默认索引0）
+func buildRLE8BMP() []byte {
+	pixelStream := []byte{
+		0x03, 0x01,
+		0x00, 0x03, 2, 3, 0, 0,
+		0x00, 0x00,
+		0x00, 0x02, 2, 0,
+		0x02, 0x03,
+		0x00, 0x01,
+	}
+
+	const width, height = 6, 2
+	pal := []color.Color{
+		color.RGBA{0, 0, 0, 255}, color.RGBA{1, 1, 1, 255},
+		color.RGBA{2, 3, 0, 255}, color.RGBA{3, 3, 3, 255},
+	}
+
+	var info bytes.Buffer
+	binary.Write(&info, binary.LittleEndian, uint32(infoHeaderSize))
+	binary.Write(&info, binary.LittleEndian, int32(width))
+	binary.Write(&info, binary.LittleEndian, int32(height))
+	binary.Write(&info, binary.LittleEndian, uint16(1))
+	binary.Write(&info, binary.LittleEndian, uint16(8))
+	binary.Write(&info, binary.LittleEndian, uint32(biRLE8))
+	binary.Write(&info, binary.LittleEndian, uint32(len(pixelStream)))
+	binary.Write(&info, binary.LittleEndian, int32(0))
+	binary.Write(&info, binary.LittleEndian, int32(0))
+	binary.Write(&info, binary.LittleEndian, uint32(len(pal)))
+	binary.Write(&info, binary.LittleEndian, uint32(0))
+
+	var paletteBytes bytes.Buffer
+	for _, c := range pal {
+		r, g, b, _ := c.RGBA()
+		paletteBytes.Write([]byte{byte(b >> 8), byte(g >> 8), byte(r >> 8), 0})
+	}
+
+	dataOffset := bmpFileHeaderSize + info.Len() + paletteBytes.Len()
+
+	var file bytes.Buffer
+	file.WriteString("BM")
+	binary.Write(&file, binary.LittleEndian, uint32(0)) // FileSize，稍后回填
+	binary.Write(&file, binary.LittleEndian, uint32(0)) // Reserved
+	binary.Write(&file, binary.LittleEndian, uint32(dataOffset))
+	file.Write(info.Bytes())
+	file.Write(paletteBytes.Bytes())
+	file.Write(pixelStream)
+
+	buf := file.Bytes()
+	binary.LittleEndian.PutUint32(buf[2:6], uint32(len(buf)))
+	return buf
+}
+
+// 验证 readRLEData 的绝对模式（字面像素 + 补齐）和增量移动分支，
+// EncodeRLE 自身只会生成编码模式 (count,index)，无法覆盖这两条解码路径。
+func TestDecodeRLE8AbsoluteModeAndDelta(t *testing.T) {
+	pal := color.Palette{
+		color.RGBA{0, 0, 0, 255}, color.RGBA{1, 1, 1, 255},
+		color.RGBA{2, 3, 0, 255}, color.RGBA{3, 3, 3, 255},
+	}
+
+	got, err := Decode(bytes.NewReader(buildRLE8BMP()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRow1 := []color.Color{pal[1], pal[1], pal[1], pal[2], pal[3], pal[0]}
+	for x, want := range wantRow1 {
+		if c := got.At(x, 1).(color.RGBA); c != want {
+			t.Errorf("(%d,1) 颜色不符合预期: %+v, want %+v", x, c, want)
+		}
+	}
+
+	wantRow0 := []color.Color{pal[0], pal[0], pal[3], pal[3], pal[0], pal[0]}
+	for x, want := range wantRow0 {
+		if c := got.At(x, 0).(color.RGBA); c != want {
+			t.Errorf("(%d,0) 颜色不符合预期: %+v, want %+v", x, c, want)
+		}
+	}
+}
+
+// 验证 BI_RLE8 压缩的调色板图像能被正确还原，包括未写入像素默认回落到索引 0
+func TestDecodeRLE8(t *testing.T) {
+	pal := color.Palette{color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}, color.RGBA{255, 0, 0, 255}}
+	// 调色板超过 16 项时 EncodeRLE 才会选用 8 位色深，即 BI_RLE8
+	for len(pal) < 256 {
+		pal = append(pal, color.RGBA{A: 255})
+	}
+	src := image.NewPaletted(image.Rect(0, 0, 6, 4), pal)
+	src.SetColorIndex(1, 1, 2)
+	src.SetColorIndex(2, 1, 2)
+	src.SetColorIndex(5, 3, 1)
+
+	var buf bytes.Buffer
+	if err := EncodeRLE(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := got.At(1, 1).(color.RGBA); c != pal[2] {
+		t.Errorf("(1,1) 颜色不符合预期: %+v", c)
+	}
+	if c := got.At(5, 3).(color.RGBA); c != pal[1] {
+		t.Errorf("(5,3) 颜色不符合预期: %+v", c)
+	}
+	if c := got.At(0, 0).(color.RGBA); c != pal[0] {
+		t.Errorf("未写入像素应默认回落到调色板索引 0，实际 %+v", c)
+	}
+}
+
+// 验证 BI_RLE4 压缩（每字节两个下标）也能正确还原
+func TestDecodeRLE4(t *testing.T) {
+	pal := color.Palette{
+		color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255},
+		color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255},
+	}
+	src := image.NewPaletted(image.Rect(0, 0, 7, 1), pal)
+	for x := 0; x < 7; x++ {
+		src.SetColorIndex(x, 0, uint8(x%4))
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeRLE(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 7; x++ {
+		want := pal[x%4]
+		if c := got.At(x, 0).(color.RGBA); c != want {
+			t.Errorf("x=%d 颜色不符合预期: %+v, want %+v", x, c, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package xbmp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// nonSeekingReader 包装 bufio.Reader 但不暴露 io.Seeker，用来模拟管道、HTTP
+// 响应体一类只能顺序读取的数据源。
+type nonSeekingReader struct {
+	r *bufio.Reader
+}
+
+func (n *nonSeekingReader) Read(p []byte) (int, error) { return n.r.Read(p) }
+
+// 验证 Decode 在没有 io.Seeker 的情况下也能通过丢弃中间字节跳到像素数据
+func TestDecodeWithoutSeeker(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, color.RGBA{10, 20, 30, 255})
+	src.Set(2, 1, color.RGBA{40, 50, 60, 128})
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(&nonSeekingReader{r: bufio.NewReader(bytes.NewReader(buf.Bytes()))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := got.At(0, 0).(color.RGBA); c.R != 10 || c.G != 20 || c.B != 30 {
+		t.Errorf("颜色值不符合预期: %+v", c)
+	}
+}
+
+// 验证对不可定位的读取器，一个伪造的、与已读字节的差距超过 maxDataOffsetGap
+// 的 DataOffset 会直接报错，而不是无限制地丢弃数据。
+func TestDecodeWithoutSeekerRejectsOversizedDataOffset(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := buf.Bytes()
+	binary.LittleEndian.PutUint32(raw[10:14], 0xFFFFFFF0) // DataOffset，远超 FileSize
+
+	_, err := Decode(&nonSeekingReader{r: bufio.NewReader(bytes.NewReader(raw))})
+	if err == nil {
+		t.Fatal("期望因 DataOffset 越界而报错，实际未报错")
+	}
+}
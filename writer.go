@@ -0,0 +1,326 @@
+package xbmp
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// BitmapFile 表示一个待写出的 BMP 文件：文件头、信息头、可选调色板和像素数据。
+// Pix 按自下而上的顺序存储，每行按 Stride 对齐到 4 字节边界。
+type BitmapFile struct {
+	File    FileHeader
+	Info    InfoHeader
+	Palette []color.Color
+	Pix     []byte
+	Stride  int
+}
+
+// NewBitmapFile 按给定尺寸和色深创建一个未压缩（BI_RGB）的 BitmapFile，
+// 并分配好自下而上、4 字节对齐的像素缓冲区。
+func NewBitmapFile(width, height int32, bitCount uint16) *BitmapFile {
+	stride := rowStride(int(width), bitCount)
+	dataSize := stride * int(height)
+
+	var paletteBytes int
+	if bitCount <= 8 {
+		paletteBytes = (1 << bitCount) * 4
+	}
+	dataOffset := bmpFileHeaderSize + infoHeaderSize + paletteBytes
+
+	return &BitmapFile{
+		File: FileHeader{
+			Signature:  [2]byte{'B', 'M'},
+			FileSize:   uint32(dataOffset + dataSize),
+			DataOffset: uint32(dataOffset),
+		},
+		Info: InfoHeader{
+			Size:        infoHeaderSize,
+			Width:       width,
+			Height:      height,
+			Planes:      1,
+			BitCount:    bitCount,
+			Compression: biRGB,
+			SizeImage:   uint32(dataSize),
+		},
+		Stride: stride,
+		Pix:    make([]byte, dataSize),
+	}
+}
+
+// rowStride 计算给定宽度和色深下每行按 4 字节对齐后的字节数。
+func rowStride(width int, bitCount uint16) int {
+	return (width*int(bitCount) + 31) / 32 * 4
+}
+
+// rowOffset 返回图像第 y 行（自顶向下计数）在自下而上存储的 Pix 中的起始偏移。
+func (bf *BitmapFile) rowOffset(y int) int {
+	return (int(bf.Info.Height) - 1 - y) * bf.Stride
+}
+
+// WriteTo 按 BITMAPFILEHEADER + BITMAPINFOHEADER + 调色板 + 像素数据的顺序写出。
+func (bf *BitmapFile) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+
+	if err := binary.Write(w, binary.LittleEndian, bf.File); err != nil {
+		return written, err
+	}
+	written += bmpFileHeaderSize
+
+	hdr := make([]byte, infoHeaderSize)
+	binary.LittleEndian.PutUint32(hdr[0:4], bf.Info.Size)
+	binary.LittleEndian.PutUint32(hdr[4:8], uint32(bf.Info.Width))
+	binary.LittleEndian.PutUint32(hdr[8:12], uint32(bf.Info.Height))
+	binary.LittleEndian.PutUint16(hdr[12:14], bf.Info.Planes)
+	binary.LittleEndian.PutUint16(hdr[14:16], bf.Info.BitCount)
+	binary.LittleEndian.PutUint32(hdr[16:20], bf.Info.Compression)
+	binary.LittleEndian.PutUint32(hdr[20:24], bf.Info.SizeImage)
+	binary.LittleEndian.PutUint32(hdr[24:28], uint32(bf.Info.XPelsPerMeter))
+	binary.LittleEndian.PutUint32(hdr[28:32], uint32(bf.Info.YPelsPerMeter))
+	binary.LittleEndian.PutUint32(hdr[32:36], bf.Info.ClrUsed)
+	binary.LittleEndian.PutUint32(hdr[36:40], bf.Info.ClrImportant)
+	if _, err := w.Write(hdr); err != nil {
+		return written, err
+	}
+	written += int64(len(hdr))
+
+	if bf.Info.BitCount <= 8 {
+		for _, c := range paletteOrDefault(bf.Palette, bf.Info.BitCount) {
+			r, g, b, _ := c.RGBA()
+			entry := [4]byte{byte(b >> 8), byte(g >> 8), byte(r >> 8), 0}
+			if _, err := w.Write(entry[:]); err != nil {
+				return written, err
+			}
+			written += 4
+		}
+	}
+
+	n, err := w.Write(bf.Pix)
+	written += int64(n)
+	return written, err
+}
+
+// paletteOrDefault 补齐调色板到 2^bitCount 项，缺失的条目填充为黑色。
+func paletteOrDefault(palette []color.Color, bitCount uint16) []color.Color {
+	entries := 1 << bitCount
+	if len(palette) >= entries {
+		return palette[:entries]
+	}
+	full := make([]color.Color, entries)
+	copy(full, palette)
+	for i := len(palette); i < entries; i++ {
+		full[i] = color.RGBA{A: 255}
+	}
+	return full
+}
+
+// Encode 将 m 写为未压缩的 BMP。根据具体类型选用 1/4/8 位调色板、
+// 24 位 RGB 或 32 位 BGRA，其余类型退回到 24 位 RGB。
+func Encode(w io.Writer, m image.Image) error {
+	bf, err := newBitmapFileFor(m)
+	if err != nil {
+		return err
+	}
+	_, err = bf.WriteTo(w)
+	return err
+}
+
+// EncodeRLE 与 Encode 类似，但对 4/8 位调色板图像使用 BI_RLE4/BI_RLE8 压缩；
+// 其余类型没有压缩形式可用，退回到 Encode。
+func EncodeRLE(w io.Writer, m image.Image) error {
+	bf, err := newBitmapFileFor(m)
+	if err != nil {
+		return err
+	}
+	if bf.Info.BitCount != 4 && bf.Info.BitCount != 8 {
+		return Encode(w, m)
+	}
+	bf.compressRLE()
+	_, err = bf.WriteTo(w)
+	return err
+}
+
+func newBitmapFileFor(m image.Image) (*BitmapFile, error) {
+	bounds := m.Bounds()
+	width, height := int32(bounds.Dx()), int32(bounds.Dy())
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("xbmp: cannot encode an empty image")
+	}
+
+	switch src := m.(type) {
+	case *image.Paletted:
+		bitCount := paletteBitCount(len(src.Palette))
+		bf := NewBitmapFile(width, height, bitCount)
+		bf.Palette = src.Palette
+		fillIndexedPix(bf, src)
+		return bf, nil
+	case *image.Gray:
+		bf := NewBitmapFile(width, height, 8)
+		bf.Palette = grayPalette()
+		fillGrayPix(bf, src)
+		return bf, nil
+	case *image.RGBA:
+		bf := NewBitmapFile(width, height, 32)
+		fillBGRAPix(bf, src.Pix, src.Stride)
+		return bf, nil
+	case *image.NRGBA:
+		bf := NewBitmapFile(width, height, 32)
+		fillBGRAPix(bf, src.Pix, src.Stride)
+		return bf, nil
+	default:
+		bf := NewBitmapFile(width, height, 24)
+		fillRGBPixGeneric(bf, m)
+		return bf, nil
+	}
+}
+
+// paletteBitCount 选择能容纳 n 个调色板项的最小色深（1/4/8 位）。
+func paletteBitCount(n int) uint16 {
+	switch {
+	case n <= 2:
+		return 1
+	case n <= 16:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// grayPalette 生成一个 256 级灰度调色板，供 *image.Gray 编码使用。
+func grayPalette() []color.Color {
+	p := make([]color.Color, 256)
+	for i := range p {
+		p[i] = color.RGBA{uint8(i), uint8(i), uint8(i), 255}
+	}
+	return p
+}
+
+// fillIndexedPix 将调色板图像的索引按 bf.Info.BitCount 打包进自下而上的 Pix 缓冲区。
+func fillIndexedPix(bf *BitmapFile, src *image.Paletted) {
+	width := int(bf.Info.Width)
+	height := int(bf.Info.Height)
+	for y := 0; y < height; y++ {
+		rowStart := bf.rowOffset(y)
+		srcRow := src.Pix[y*src.Stride : y*src.Stride+width]
+		packIndices(bf.Pix[rowStart:rowStart+bf.Stride], srcRow, bf.Info.BitCount)
+	}
+}
+
+// fillGrayPix 将 8 位灰度数据按自下而上顺序拷入 Pix 缓冲区。
+func fillGrayPix(bf *BitmapFile, src *image.Gray) {
+	width := int(bf.Info.Width)
+	height := int(bf.Info.Height)
+	for y := 0; y < height; y++ {
+		rowStart := bf.rowOffset(y)
+		copy(bf.Pix[rowStart:rowStart+width], src.Pix[y*src.Stride:y*src.Stride+width])
+	}
+}
+
+// fillBGRAPix 把按 R,G,B,A 顺序存储的源像素（image.RGBA 与 image.NRGBA 共用此布局）
+// 逐行重排为 BMP 的 B,G,R,A 顺序，写入自下而上的 Pix 缓冲区。
+func fillBGRAPix(bf *BitmapFile, srcPix []byte, srcStride int) {
+	width := int(bf.Info.Width)
+	height := int(bf.Info.Height)
+	for y := 0; y < height; y++ {
+		rowStart := bf.rowOffset(y)
+		dstRow := bf.Pix[rowStart : rowStart+bf.Stride]
+		srcRow := srcPix[y*srcStride : y*srcStride+width*4]
+		for x := 0; x < width; x++ {
+			so, do := x*4, x*4
+			dstRow[do+0] = srcRow[so+2] // B
+			dstRow[do+1] = srcRow[so+1] // G
+			dstRow[do+2] = srcRow[so+0] // R
+			dstRow[do+3] = srcRow[so+3] // A
+		}
+	}
+}
+
+// fillRGBPixGeneric 是未识别具体像素格式时的兜底路径：通过 At(x, y).RGBA()
+// 逐像素转换为 24 位 RGB。
+func fillRGBPixGeneric(bf *BitmapFile, m image.Image) {
+	b := m.Bounds()
+	width := int(bf.Info.Width)
+	height := int(bf.Info.Height)
+	for y := 0; y < height; y++ {
+		rowStart := bf.rowOffset(y)
+		row := bf.Pix[rowStart : rowStart+bf.Stride]
+		for x := 0; x < width; x++ {
+			r, g, bl, _ := m.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			o := x * 3
+			row[o+0] = byte(bl >> 8)
+			row[o+1] = byte(g >> 8)
+			row[o+2] = byte(r >> 8)
+		}
+	}
+}
+
+// packIndices 把 indices（每项一个像素的调色板下标）按 bitCount 打包进 dst，
+// 打包方式与 readIndexedData 的解包方式一一对应。
+func packIndices(dst []byte, indices []byte, bitCount uint16) {
+	switch bitCount {
+	case 8:
+		copy(dst, indices)
+	case 4:
+		for x, idx := range indices {
+			shift := uint(x%2) * 4
+			dst[x/2] |= (idx & 0x0F) << shift
+		}
+	case 1:
+		for x, idx := range indices {
+			if idx&1 == 1 {
+				dst[x/8] |= 1 << uint(7-x%8)
+			}
+		}
+	}
+}
+
+// compressRLE 将已填充的未压缩索引数据就地替换为 BI_RLE4/BI_RLE8 编码模式的字节流，
+// 并更新相应的头部字段。
+func (bf *BitmapFile) compressRLE() {
+	width := int(bf.Info.Width)
+	height := int(bf.Info.Height)
+
+	// Pix 本身已按文件顺序（自下而上）存储，这里按存储顺序逐行编码，
+	// 不能用 rowOffset 按显示行号取行，否则会把图像编码成上下颠倒。
+	var encoded []byte
+	for i := 0; i < height; i++ {
+		row := bf.Pix[i*bf.Stride : i*bf.Stride+bf.Stride]
+		indices := make([]byte, width)
+		unpackIndices(indices, row, bf.Info.BitCount)
+		encoded = append(encoded, encodeRLERow(indices, bf.Info.BitCount)...)
+		encoded = append(encoded, 0x00, 0x00) // 行结束
+	}
+	encoded = append(encoded, 0x00, 0x01) // 位图结束
+
+	bf.Pix = encoded
+	if bf.Info.BitCount == 8 {
+		bf.Info.Compression = biRLE8
+	} else {
+		bf.Info.Compression = biRLE4
+	}
+	bf.Info.SizeImage = uint32(len(encoded))
+	bf.File.FileSize = bf.File.DataOffset + uint32(len(encoded))
+}
+
+// encodeRLERow 把一行调色板下标编码为连续的 (count, index) 记录。
+func encodeRLERow(indices []byte, bitCount uint16) []byte {
+	var out []byte
+	for i := 0; i < len(indices); {
+		j := i + 1
+		for j < len(indices) && j-i < 255 && indices[j] == indices[i] {
+			j++
+		}
+		count := byte(j - i)
+		var value byte
+		if bitCount == 4 {
+			value = indices[i]<<4 | indices[i]
+		} else {
+			value = indices[i]
+		}
+		out = append(out, count, value)
+		i = j
+	}
+	return out
+}
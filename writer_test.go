@@ -0,0 +1,73 @@
+package xbmp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// 验证 RGBA 图像经 Encode 后能被 Decode 正确还原
+func TestEncodeDecodeRGBA(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, color.RGBA{10, 20, 30, 255})
+	src.Set(2, 1, color.RGBA{40, 50, 60, 128})
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := got.At(0, 0).(color.RGBA)
+	if c.R != 10 || c.G != 20 || c.B != 30 {
+		t.Errorf("颜色值不符合预期: %+v", c)
+	}
+}
+
+// 验证 EncodeRLE 输出的压缩数据能被 Decode 正确还原，包括按行是否上下颠倒
+// （EncodeRLE 必须按 Pix 的存储顺序——自下而上——逐行编码，而不是按显示行号）
+func TestEncodeRLERoundTrip(t *testing.T) {
+	pal := color.Palette{color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}, color.RGBA{0, 255, 0, 255}}
+	src := image.NewPaletted(image.Rect(0, 0, 4, 3), pal)
+	src.SetColorIndex(0, 0, 1)
+	src.SetColorIndex(3, 2, 2)
+
+	var buf bytes.Buffer
+	if err := EncodeRLE(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := got.At(0, 0).(color.RGBA); c != pal[1] {
+		t.Errorf("(0,0) 颜色不符合预期: %+v", c)
+	}
+	if c := got.At(3, 2).(color.RGBA); c != pal[2] {
+		t.Errorf("(3,2) 颜色不符合预期: %+v", c)
+	}
+	if c := got.At(1, 1).(color.RGBA); c != pal[0] {
+		t.Errorf("未写入像素应默认回落到调色板索引 0，实际 %+v", c)
+	}
+}
+
+func TestDecodeConfig(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 5, 4))
+	var buf bytes.Buffer
+	if err := Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := DecodeConfig(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 5 || cfg.Height != 4 {
+		t.Errorf("尺寸不符合预期: %dx%d", cfg.Width, cfg.Height)
+	}
+}